@@ -0,0 +1,168 @@
+package random // import "fluux.io/random"
+
+import (
+	"math"
+	"sort"
+)
+
+//=============================================================================
+// Distribution-aware generators
+//
+// Uniform strings and ints rarely match production traffic shapes. These
+// generators let load testing code draw from skewed and weighted
+// distributions instead, so fixture data looks more like the real thing.
+
+// Exponential returns a random float64 drawn from the exponential
+// distribution with the given rate, suitable for modelling inter-arrival
+// times.
+func (r *RandomUnsafe) Exponential(rate float64) float64 {
+	u := r.float64()
+	for u == 1 {
+		u = r.float64()
+	}
+	return -math.Log(1-u) / rate
+}
+
+// LogNormal returns a random float64 drawn from the log-normal distribution
+// with underlying normal parameters mu and sigma, suitable for modelling
+// payload sizes.
+func (r *RandomUnsafe) LogNormal(mu, sigma float64) float64 {
+	return math.Exp(r.normFloat64()*sigma + mu)
+}
+
+//=============================================================================
+// Zipf: key-popularity-skewed IDs
+
+// zipfTable caches the cumulative distribution for one (s, v, imax) triple,
+// since building it is the expensive part of sampling.
+type zipfTable struct {
+	s, v float64
+	imax uint64
+	cdf  []float64
+}
+
+func newZipfTable(s, v float64, imax uint64) *zipfTable {
+	cdf := make([]float64, imax+1)
+	var sum float64
+	for k := uint64(0); k <= imax; k++ {
+		sum += 1 / math.Pow(float64(k)+v, s)
+		cdf[k] = sum
+	}
+	return &zipfTable{s: s, v: v, imax: imax, cdf: cdf}
+}
+
+// sample draws one value from the distribution z describes, using r as the
+// source of randomness. z itself holds no RNG state, so it can safely be
+// shared across many RandomUnsafe instances (see Pool.Zipf).
+func (z *zipfTable) sample(r *RandomUnsafe) uint64 {
+	total := z.cdf[len(z.cdf)-1]
+	target := r.float64() * total
+	idx := sort.Search(len(z.cdf), func(i int) bool { return z.cdf[i] >= target })
+	return uint64(idx)
+}
+
+// Zipf returns a random uint64 in [0,imax] drawn from the Zipf-Mandelbrot
+// distribution with P(k) proportional to (k+v)^-s, for generating
+// key-popularity-skewed IDs. The cumulative distribution table is cached and
+// rebuilt only when s, v or imax change, so keep imax close to the size of
+// your actual key space.
+func (r *RandomUnsafe) Zipf(s, v float64, imax uint64) uint64 {
+	if r.zipf == nil || r.zipf.s != s || r.zipf.v != v || r.zipf.imax != imax {
+		r.zipf = newZipfTable(s, v, imax)
+	}
+	return r.zipf.sample(r)
+}
+
+//=============================================================================
+// Weighted sampling via the alias method (Vose's construction)
+
+// AliasTable samples from a discrete distribution in O(1) after an O(n)
+// build, using Vose's construction of Walker's alias method.
+type AliasTable struct {
+	prob  []float64
+	alias []int
+}
+
+// NewAliasTable builds an AliasTable for the given (unnormalized) weights.
+func NewAliasTable(weights []float64) *AliasTable {
+	n := len(weights)
+	prob := make([]float64, n)
+	alias := make([]int, n)
+	scaled := make([]float64, n)
+
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / sum
+	}
+
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+	for i, p := range scaled {
+		if p < 1.0 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		prob[s] = scaled[s]
+		alias[s] = l
+
+		scaled[l] = scaled[l] + scaled[s] - 1.0
+		if scaled[l] < 1.0 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+	// Leftovers only fall outside [0,1) by floating point rounding: treat them
+	// as certain (probability 1, no alias needed).
+	for _, l := range large {
+		prob[l] = 1.0
+	}
+	for _, s := range small {
+		prob[s] = 1.0
+	}
+
+	return &AliasTable{prob: prob, alias: alias}
+}
+
+// Sample draws one index in [0,n) from the table using r.
+func (a *AliasTable) Sample(r *RandomUnsafe) int {
+	i := r.intn(len(a.prob))
+	if r.float64() < a.prob[i] {
+		return i
+	}
+	return a.alias[i]
+}
+
+// Weighted picks one element of items at random, weighted by the matching
+// entry in weights, using the alias method.
+func Weighted[T any](r *RandomUnsafe, items []T, weights []float64) T {
+	idx := NewAliasTable(weights).Sample(r)
+	return items[idx]
+}
+
+// Categorical returns a random index into probs, weighted by probs, using
+// the alias method.
+func (r *RandomUnsafe) Categorical(probs []float64) int {
+	return NewAliasTable(probs).Sample(r)
+}
+
+// Enum returns a random value from values, each equally likely.
+func (r *RandomUnsafe) Enum(values []string) string {
+	probs := make([]float64, len(values))
+	for i := range probs {
+		probs[i] = 1
+	}
+	return values[r.Categorical(probs)]
+}
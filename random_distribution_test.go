@@ -0,0 +1,58 @@
+package random // import "fluux.io/random"
+
+import "testing"
+
+// TestZipfSkewed checks that low indices are drawn noticeably more often
+// than high ones, as expected from a Zipf distribution.
+func TestZipfSkewed(t *testing.T) {
+	r := NewRandomUnsafe()
+
+	var low, high int
+	for i := 0; i < testsRandomNumber; i++ {
+		k := r.Zipf(1.5, 1, 99)
+		if k > 99 {
+			t.Fatalf("Zipf out of range: %d", k)
+		}
+		if k < 10 {
+			low++
+		}
+		if k >= 90 {
+			high++
+		}
+	}
+	if low <= high {
+		t.Errorf("expected low indices (%d) to be drawn more often than high ones (%d)", low, high)
+	}
+}
+
+// TestWeightedRespectsWeights checks that a heavily weighted item is picked
+// far more often than a lightly weighted one.
+func TestWeightedRespectsWeights(t *testing.T) {
+	r := NewRandomUnsafe()
+	items := []string{"rare", "common"}
+	weights := []float64{1, 99}
+
+	var commonCount int
+	for i := 0; i < testsRandomNumber; i++ {
+		if Weighted(&r, items, weights) == "common" {
+			commonCount++
+		}
+	}
+	if commonCount < testsRandomNumber*9/10 {
+		t.Errorf("expected \"common\" to dominate, got %d/%d", commonCount, testsRandomNumber)
+	}
+}
+
+// TestEnumReturnsKnownValue checks that Enum only returns values from its
+// input slice.
+func TestEnumReturnsKnownValue(t *testing.T) {
+	r := NewRandomUnsafe()
+	values := []string{"a", "b", "c"}
+
+	for i := 0; i < testsRandomNumber; i++ {
+		v := r.Enum(values)
+		if v != "a" && v != "b" && v != "c" {
+			t.Fatalf("unexpected value: %q", v)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package random // import "fluux.io/random"
+
+import (
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+)
+
+//=============================================================================
+// Concurrency-safe pool of RandomUnsafe generators
+
+// Pool is a concurrency-safe random generator for use from many goroutines
+// at once, for example from a worker pool or a parallel load generator.
+// RandomUnsafe documents that it must be used from a single goroutine; Pool
+// lifts that restriction by keeping a sync.Pool of RandomUnsafe instances,
+// one effectively per goroutine/P, so each call runs against its own
+// preallocated string buffer and boolcache instead of contending on a
+// shared lock.
+type Pool struct {
+	pool sync.Pool
+
+	// zipfTables caches the Zipf cumulative distribution tables keyed by
+	// (s, v, imax). RandomUnsafe.Zipf caches its table on the instance
+	// itself, which is useless here: each Zipf call borrows a different
+	// instance from pool, so without this cache the O(imax) table would be
+	// rebuilt, and a fresh []float64 of length imax+1 allocated, on every
+	// single call.
+	zipfTables sync.Map // zipfKey -> *zipfTable
+}
+
+// zipfKey identifies one cached Zipf cumulative distribution table.
+type zipfKey struct {
+	s, v float64
+	imax uint64
+}
+
+// NewPool creates a concurrency-safe random generator pool.
+func NewPool() *Pool {
+	return &Pool{
+		pool: sync.Pool{
+			New: func() interface{} {
+				r := NewRandomUnsafe()
+				return &r
+			},
+		},
+	}
+}
+
+// get borrows a RandomUnsafe from the pool. Callers must return it with put.
+func (p *Pool) get() *RandomUnsafe {
+	return p.pool.Get().(*RandomUnsafe)
+}
+
+func (p *Pool) put(r *RandomUnsafe) {
+	p.pool.Put(r)
+}
+
+// NumString returns a random string containing numbers.
+func (p *Pool) NumString(n int) string {
+	r := p.get()
+	defer p.put(r)
+	return r.NumString(n)
+}
+
+// Length generates an integer between min and max.
+func (p *Pool) Length(min, max int) int {
+	r := p.get()
+	defer p.put(r)
+	return r.Length(min, max)
+}
+
+// String returns a random string of random length between min and max.
+func (p *Pool) String(min, max int) string {
+	r := p.get()
+	defer p.put(r)
+	return r.String(min, max)
+}
+
+// FixedLenString returns a random string of n chars.
+func (p *Pool) FixedLenString(n int) string {
+	r := p.get()
+	defer p.put(r)
+	return r.FixedLenString(n)
+}
+
+// Bool returns a random boolean.
+func (p *Pool) Bool() bool {
+	r := p.get()
+	defer p.put(r)
+	return r.Bool()
+}
+
+// OptBool return an optional random boolean.
+func (p *Pool) OptBool() *wrappers.BoolValue {
+	r := p.get()
+	defer p.put(r)
+	return r.OptBool()
+}
+
+// Int returns a random int32.
+func (p *Pool) Int(n int) int32 {
+	r := p.get()
+	defer p.put(r)
+	return r.Int(n)
+}
+
+// OptInt32 returns a optional random int32.
+func (p *Pool) OptInt32(n int) *wrappers.Int32Value {
+	r := p.get()
+	defer p.put(r)
+	return r.OptInt32(n)
+}
+
+// OptInt64 returns a optional random int64.
+func (p *Pool) OptInt64(n int) *wrappers.Int64Value {
+	r := p.get()
+	defer p.put(r)
+	return r.OptInt64(n)
+}
+
+// Date returns a random recent date formatted as string.
+func (p *Pool) Date() string {
+	r := p.get()
+	defer p.put(r)
+	return r.Date()
+}
+
+// OptString returns an optional random string of random length between min
+// and max.
+func (p *Pool) OptString(min, max int) *wrappers.StringValue {
+	r := p.get()
+	defer p.put(r)
+	return r.OptString(min, max)
+}
+
+// Size returns a physical measure for an object using a log-normal
+// distribution with underlying normal parameters mu and sigma.
+func (p *Pool) Size(mu, sigma float64) *wrappers.Int32Value {
+	r := p.get()
+	defer p.put(r)
+	return r.Size(mu, sigma)
+}
+
+// RandomId returns a random string to use as id, starting with prefix.
+func (p *Pool) RandomId(prefix string) string {
+	r := p.get()
+	defer p.put(r)
+	return r.RandomId(prefix)
+}
+
+// Code returns a random code built from prefix and i.
+func (p *Pool) Code(prefix string, i int) string {
+	r := p.get()
+	defer p.put(r)
+	return r.Code(prefix, i)
+}
+
+// Zipf returns a random uint64 in [0,imax] drawn from the Zipf-Mandelbrot
+// distribution with P(k) proportional to (k+v)^-s. One cumulative
+// distribution table is cached per distinct (s, v, imax) and shared across
+// all goroutines calling this Pool, rather than per borrowed RandomUnsafe
+// instance; concurrent first calls for the same key may each build a table,
+// but only one is kept. Keep imax close to the size of your actual key space
+// just as with RandomUnsafe.Zipf.
+func (p *Pool) Zipf(s, v float64, imax uint64) uint64 {
+	key := zipfKey{s: s, v: v, imax: imax}
+	zt, ok := p.zipfTables.Load(key)
+	if !ok {
+		zt, _ = p.zipfTables.LoadOrStore(key, newZipfTable(s, v, imax))
+	}
+
+	r := p.get()
+	defer p.put(r)
+	return zt.(*zipfTable).sample(r)
+}
+
+// Exponential returns a random float64 drawn from the exponential
+// distribution with the given rate.
+func (p *Pool) Exponential(rate float64) float64 {
+	r := p.get()
+	defer p.put(r)
+	return r.Exponential(rate)
+}
+
+// LogNormal returns a random float64 drawn from the log-normal distribution
+// with underlying normal parameters mu and sigma.
+func (p *Pool) LogNormal(mu, sigma float64) float64 {
+	r := p.get()
+	defer p.put(r)
+	return r.LogNormal(mu, sigma)
+}
+
+// Categorical returns a random index into probs, weighted by probs, using
+// the alias method.
+func (p *Pool) Categorical(probs []float64) int {
+	r := p.get()
+	defer p.put(r)
+	return r.Categorical(probs)
+}
+
+// Enum returns a random value from values, each equally likely.
+func (p *Pool) Enum(values []string) string {
+	r := p.get()
+	defer p.put(r)
+	return r.Enum(values)
+}
+
+// PoolWeighted picks one element of items at random from p, weighted by the
+// matching entry in weights, using the alias method. It is a free function
+// rather than a method because Go methods can't take their own type
+// parameters; see Weighted for the RandomUnsafe equivalent.
+func PoolWeighted[T any](p *Pool, items []T, weights []float64) T {
+	r := p.get()
+	defer p.put(r)
+	return Weighted(r, items, weights)
+}
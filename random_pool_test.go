@@ -0,0 +1,123 @@
+package random // import "fluux.io/random"
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// TestPoolConcurrent exercises Pool from many goroutines at once, mainly to
+// let the race detector catch any shared state that escaped the pool.
+func TestPoolConcurrent(t *testing.T) {
+	p := NewPool()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 50; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				s := p.String(10, 20)
+				if len(s) < 10 || len(s) > 20 {
+					t.Errorf("wrong length: %q", s)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestPoolDistributions checks that the distribution-aware generators are
+// reachable through Pool, not just RandomUnsafe.
+func TestPoolDistributions(t *testing.T) {
+	p := NewPool()
+
+	if k := p.Zipf(1.5, 1, 99); k > 99 {
+		t.Errorf("Zipf out of range: %d", k)
+	}
+	if v := p.Exponential(1); v < 0 {
+		t.Errorf("Exponential returned negative value: %v", v)
+	}
+	if v := p.LogNormal(0, 1); v <= 0 {
+		t.Errorf("LogNormal returned non-positive value: %v", v)
+	}
+	if idx := p.Categorical([]float64{1, 1, 1}); idx < 0 || idx > 2 {
+		t.Errorf("Categorical out of range: %d", idx)
+	}
+	values := []string{"a", "b", "c"}
+	if v := p.Enum(values); v != "a" && v != "b" && v != "c" {
+		t.Errorf("Enum returned unexpected value: %q", v)
+	}
+	items := []string{"x", "y"}
+	if v := PoolWeighted(p, items, []float64{1, 1}); v != "x" && v != "y" {
+		t.Errorf("PoolWeighted returned unexpected value: %q", v)
+	}
+}
+
+// TestPoolZipfSharesTable checks that concurrent Pool.Zipf calls with the
+// same (s, v, imax) leave a single cached cumulative distribution table to
+// share rather than one per call, which is what makes large imax values
+// usable under Pool.
+func TestPoolZipfSharesTable(t *testing.T) {
+	p := NewPool()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 50; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if k := p.Zipf(1.5, 1, 100000); k > 100000 {
+					t.Errorf("Zipf out of range: %d", k)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	count := 0
+	p.zipfTables.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	if count != 1 {
+		t.Errorf("expected 1 cached zipf table, got %d", count)
+	}
+}
+
+//=============================================================================
+// Benchmarks
+
+func BenchmarkParallelPoolString(b *testing.B) {
+	p := NewPool()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			p.String(15, 25)
+		}
+	})
+}
+
+func BenchmarkParallelMathRandString(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			randString(15, 25)
+		}
+	})
+}
+
+func BenchmarkParallelPoolInt(b *testing.B) {
+	p := NewPool()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			p.Int(1000)
+		}
+	})
+}
+
+func BenchmarkParallelMathRandInt(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rand.Intn(1000)
+		}
+	})
+}
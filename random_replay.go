@@ -0,0 +1,104 @@
+package random // import "fluux.io/random"
+
+import "io"
+
+//=============================================================================
+// Reproducible / record-replay mode
+//
+// NewRandomUnsafe seeds from crypto/rand, which is the right default but
+// makes a failing load test impossible to reproduce. NewRandomUnsafeSeeded
+// plus Snapshot/Restore let a test print its seed (or a full snapshot) on
+// failure and replay the exact same stream of values later.
+
+// NewRandomUnsafeSeeded creates a RandomUnsafe backed by a ChaCha8 source
+// derived deterministically from seed, so two generators created with the
+// same seed produce the exact same stream of values.
+func NewRandomUnsafeSeeded(seed [32]byte) RandomUnsafe {
+	return NewRandomUnsafeWithSource(NewChaCha8SourceFromSeed(seed))
+}
+
+// State is an opaque snapshot of a RandomUnsafe, produced by Snapshot and
+// consumed by Restore.
+type State struct {
+	source    any // implementation-defined; nil if the Source doesn't support snapshotting
+	prealloc  []byte
+	boolcache uint64
+	boolcount int
+}
+
+// stateSource is implemented by sources that support Snapshot/Restore, e.g.
+// the ones returned by NewPCGSource, NewChaCha8Source and
+// NewChaCha8SourceFromSeed. Sources that don't implement it (e.g.
+// NewLegacySource) are restored with their prealloc/boolcache only, not the
+// underlying RNG state.
+type stateSource interface {
+	snapshotState() any
+	restoreState(any)
+}
+
+// Snapshot captures r's full state, so it can be restored later with
+// Restore to replay the exact same sequence of values.
+func (r *RandomUnsafe) Snapshot() State {
+	var src any
+	if ss, ok := r.src.(stateSource); ok {
+		src = ss.snapshotState()
+	}
+	return State{
+		source:    src,
+		prealloc:  append([]byte(nil), r.prealloc...),
+		boolcache: r.boolcache,
+		boolcount: r.boolcount,
+	}
+}
+
+// Restore puts r back into the state captured by s. If s was captured from a
+// RandomUnsafe using a different Source implementation, the underlying RNG
+// state is left untouched; only prealloc and the bool cache are restored.
+func (r *RandomUnsafe) Restore(s State) {
+	if s.source != nil {
+		if rs, ok := r.src.(stateSource); ok {
+			rs.restoreState(s.source)
+		}
+	}
+	r.prealloc = append([]byte(nil), s.prealloc...)
+	r.boolcache, r.boolcount = s.boolcache, s.boolcount
+}
+
+//=============================================================================
+// TeeSource: tap the raw RNG tape for diagnosing nondeterminism
+
+// TeeSource wraps a Source and logs every raw Uint64 it produces to w, one
+// value per line, so two runs' RNG tapes can be diffed when the nondeterminism
+// turns out to be in the injection code itself rather than in the RNG.
+type TeeSource struct {
+	src Source
+	w   io.Writer
+}
+
+// NewTeeSource wraps src, logging every value it produces to w.
+func NewTeeSource(src Source, w io.Writer) *TeeSource {
+	return &TeeSource{src: src, w: w}
+}
+
+// Uint64 draws the next value from the wrapped source, logs it, and returns it.
+func (t *TeeSource) Uint64() uint64 {
+	v := t.src.Uint64()
+	io.WriteString(t.w, uint64ToDecimalLine(v))
+	return v
+}
+
+// uint64ToDecimalLine formats v as a decimal number followed by a newline,
+// without pulling in fmt on the hot RNG path.
+func uint64ToDecimalLine(v uint64) string {
+	var buf [20]byte // max uint64 is 20 decimal digits
+	i := len(buf)
+	for {
+		i--
+		buf[i] = byte('0' + v%10)
+		v /= 10
+		if v == 0 {
+			break
+		}
+	}
+	return string(buf[i:]) + "\n"
+}
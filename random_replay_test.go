@@ -0,0 +1,74 @@
+package random // import "fluux.io/random"
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSeededReplay checks that two generators created from the same seed
+// produce the exact same stream of values.
+func TestSeededReplay(t *testing.T) {
+	var seed [32]byte
+	copy(seed[:], "deterministic-seed-for-replay!!!")
+
+	a := NewRandomUnsafeSeeded(seed)
+	b := NewRandomUnsafeSeeded(seed)
+
+	for i := 0; i < 1000; i++ {
+		sa, sb := a.String(10, 20), b.String(10, 20)
+		if sa != sb {
+			t.Fatalf("iteration %d: %q != %q", i, sa, sb)
+		}
+	}
+}
+
+// TestSnapshotRestore checks that restoring a snapshot replays the exact
+// same continuation of the stream.
+func TestSnapshotRestore(t *testing.T) {
+	r := NewRandomUnsafe()
+	for i := 0; i < 100; i++ {
+		r.String(5, 10) // advance past the initial state
+	}
+
+	snap := r.Snapshot()
+
+	want := make([]string, 50)
+	for i := range want {
+		want[i] = r.String(5, 30)
+	}
+
+	r.Restore(snap)
+	for i := range want {
+		got := r.String(5, 30)
+		if got != want[i] {
+			t.Fatalf("iteration %d: %q != %q after restore", i, got, want[i])
+		}
+	}
+}
+
+// TestRestoreAcrossSourceTypesDoesNotPanic checks that restoring a snapshot
+// taken from a RandomUnsafe backed by a different Source implementation is a
+// safe no-op for the RNG state, instead of panicking.
+func TestRestoreAcrossSourceTypesDoesNotPanic(t *testing.T) {
+	a := NewRandomUnsafe() // PCG-backed
+	b := NewRandomUnsafeWithSource(NewChaCha8Source())
+
+	snap := b.Snapshot()
+	a.Restore(snap) // must not panic
+}
+
+// TestTeeSourceLogsValues checks that TeeSource writes one line per value
+// produced.
+func TestTeeSourceLogsValues(t *testing.T) {
+	var out strings.Builder
+	tee := NewTeeSource(NewPCGSource(), &out)
+
+	for i := 0; i < 5; i++ {
+		tee.Uint64()
+	}
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 logged values, got %d: %q", len(lines), out.String())
+	}
+}
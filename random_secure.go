@@ -0,0 +1,354 @@
+package random // import "fluux.io/random"
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+)
+
+//=============================================================================
+// ChaCha8-backed secure random generator
+//
+// RandomSecure offers the same string, number, bool, date, id and size
+// generators as RandomUnsafe (it has no Code and no distribution-aware
+// methods such as Zipf or Weighted) but is backed by a
+// ChaCha8 stream cipher seeded from crypto/rand, so its output is not
+// predictable. Use it instead of RandomUnsafe whenever generated values end
+// up in signed tokens, ids used as nonces, or other anti-replay fields.
+//
+// This costs noticeably more than RandomUnsafe: RandomUnsafe.FixedLenString
+// draws a single random offset and slices a shared prealloc buffer, so it's
+// O(1) regardless of length, while RandomSecure draws and rejection-samples
+// a fresh keystream byte per character, so it's O(n). Sharing RandomUnsafe's
+// buffer-slicing trick here would make outputs from nearby calls overlap,
+// which defeats the point of an unpredictable generator.
+//
+// The original request for this type asked for benchmarks demonstrating it
+// "stays within ~2-3x the speed of RandomUnsafe." It does not meet that
+// target: BenchmarkRandomSecureString runs at roughly 15x BenchmarkRandomString,
+// not 2-3x. fillLetters (below) removes the per-character nextByte call in
+// favor of reading the ring buffer directly, which is the only optimization
+// that was tried; it helped but didn't come close to closing the gap. The
+// remaining cost is the fresh keystream draw per character described above,
+// and closing it further would mean reusing keystream bytes across calls,
+// which this type cannot do without weakening its unpredictability
+// guarantee. So this is a known, flagged deviation from the original
+// target, not a claim that the target was met.
+
+const (
+	chachaBlockSize   = 64 // bytes produced by a single ChaCha8 block
+	chachaRingBlocks  = 16 // blocks kept in the keystream ring buffer
+	chachaRingSize    = chachaRingBlocks * chachaBlockSize
+	chachaReseedAfter = 1 << 20 // reseed the key every this many blocks
+)
+
+// chachaConstants are the 4 constant words that open the initial 16 word
+// ChaCha state, followed by 8 key words, a 32 bit block counter and a 96 bit
+// nonce.
+var chachaConstants = [4]uint32{0x61707865, 0x3320646e, 0x79622d32, 0x6b206574}
+
+func rotl32(x uint32, n uint) uint32 {
+	return x<<n | x>>(32-n)
+}
+
+func chachaQuarterRound(a, b, c, d *uint32) {
+	*a += *b
+	*d ^= *a
+	*d = rotl32(*d, 16)
+	*c += *d
+	*b ^= *c
+	*b = rotl32(*b, 12)
+	*a += *b
+	*d ^= *a
+	*d = rotl32(*d, 8)
+	*c += *d
+	*b ^= *c
+	*b = rotl32(*b, 7)
+}
+
+// chacha8Block runs the 8 round (4 column + 4 diagonal) ChaCha mixing
+// function over the state built from key, counter and nonce, and returns the
+// resulting 64 byte keystream block.
+func chacha8Block(key [8]uint32, counter uint32, nonce [3]uint32) [chachaBlockSize]byte {
+	state := [16]uint32{
+		chachaConstants[0], chachaConstants[1], chachaConstants[2], chachaConstants[3],
+		key[0], key[1], key[2], key[3], key[4], key[5], key[6], key[7],
+		counter, nonce[0], nonce[1], nonce[2],
+	}
+	working := state
+
+	for i := 0; i < 4; i++ {
+		chachaQuarterRound(&working[0], &working[4], &working[8], &working[12])
+		chachaQuarterRound(&working[1], &working[5], &working[9], &working[13])
+		chachaQuarterRound(&working[2], &working[6], &working[10], &working[14])
+		chachaQuarterRound(&working[3], &working[7], &working[11], &working[15])
+		chachaQuarterRound(&working[0], &working[5], &working[10], &working[15])
+		chachaQuarterRound(&working[1], &working[6], &working[11], &working[12])
+		chachaQuarterRound(&working[2], &working[7], &working[8], &working[13])
+		chachaQuarterRound(&working[3], &working[4], &working[9], &working[14])
+	}
+
+	var out [chachaBlockSize]byte
+	for i := 0; i < 16; i++ {
+		binary.LittleEndian.PutUint32(out[i*4:], working[i]+state[i])
+	}
+	return out
+}
+
+// seedKeyNonce fills key and nonce with fresh randomness from crypto/rand.
+func seedKeyNonce(key *[8]uint32, nonce *[3]uint32) {
+	var raw [44]byte // 8*4 key bytes + 3*4 nonce bytes
+	if _, err := rand.Read(raw[:]); err != nil {
+		panic("random: failed to read crypto/rand seed: " + err.Error())
+	}
+	for i := range key {
+		key[i] = binary.LittleEndian.Uint32(raw[i*4:])
+	}
+	for i := range nonce {
+		nonce[i] = binary.LittleEndian.Uint32(raw[32+i*4:])
+	}
+}
+
+// RandomSecure is a ChaCha8 backed random generator with RandomUnsafe's
+// string, number, bool, date, id and size generators (but not Code or the
+// distribution-aware methods), for use when the generated values must not be predictable
+// (e.g. when they end up in signed tokens or anti-replay fields).
+// It is not safe for concurrent use; use one instance per goroutine.
+type RandomSecure struct {
+	key     [8]uint32
+	nonce   [3]uint32
+	counter uint32
+	blocks  uint64 // blocks produced since the last reseed
+
+	buf []byte // keystream ring buffer
+	pos int    // next unread byte in buf
+
+	boolcache int64
+	boolcount int
+}
+
+// NewRandomSecure creates a ChaCha8 backed random generator seeded from
+// crypto/rand.
+func NewRandomSecure() RandomSecure {
+	r := RandomSecure{buf: make([]byte, chachaRingSize), pos: chachaRingSize}
+	seedKeyNonce(&r.key, &r.nonce)
+	return r
+}
+
+// refill produces chachaRingBlocks more blocks of keystream, reseeding the
+// key from crypto/rand every chachaReseedAfter blocks for forward secrecy.
+func (r *RandomSecure) refill() {
+	for i := 0; i < chachaRingBlocks; i++ {
+		block := chacha8Block(r.key, r.counter, r.nonce)
+		copy(r.buf[i*chachaBlockSize:], block[:])
+
+		r.counter++
+		if r.counter == 0 {
+			r.nonce[0]++ // extremely unlikely; keeps the stream from repeating
+		}
+		r.blocks++
+	}
+	r.pos = 0
+
+	if r.blocks >= chachaReseedAfter {
+		seedKeyNonce(&r.key, &r.nonce)
+		r.counter, r.blocks = 0, 0
+	}
+}
+
+func (r *RandomSecure) nextByte() byte {
+	if r.pos >= len(r.buf) {
+		r.refill()
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *RandomSecure) nextUint64() uint64 {
+	var b [8]byte
+	for i := range b {
+		b[i] = r.nextByte()
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// int63 returns a non-negative pseudo-random 63 bit integer.
+func (r *RandomSecure) int63() int64 {
+	return int64(r.nextUint64() &^ (1 << 63))
+}
+
+// int63n returns a non-negative pseudo-random integer in [0,n) without the
+// modulo bias that a plain `% n` reduction has for non-power-of-two n. It
+// uses the same rejection approach as math/rand.Int63n.
+func (r *RandomSecure) int63n(n int64) int64 {
+	if n <= 0 {
+		panic("random: invalid argument to int63n")
+	}
+	if n&(n-1) == 0 { // n is a power of two
+		return r.int63() & (n - 1)
+	}
+	max := int64(1<<63 - 1 - (1<<63)%uint64(n))
+	v := r.int63()
+	for v > max {
+		v = r.int63()
+	}
+	return v % n
+}
+
+func (r *RandomSecure) intn(n int) int {
+	return int(r.int63n(int64(n)))
+}
+
+func (r *RandomSecure) float64() float64 {
+	return float64(r.nextUint64()>>11) / (1 << 53)
+}
+
+func (r *RandomSecure) normFloat64() float64 {
+	u1 := r.float64()
+	for u1 == 0 {
+		u1 = r.float64()
+	}
+	u2 := r.float64()
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
+// fillLetters fills b with bytes drawn from the keystream and mapped onto
+// alphabet using rejection sampling, so every letter has exactly the same
+// probability regardless of len(alphabet) not dividing 256 evenly. It reads
+// the ring buffer directly instead of going through nextByte per character,
+// since this runs once per output character and is RandomSecure's hot path.
+func (r *RandomSecure) fillLetters(b []byte, alphabet string) {
+	limit := 256 - 256%len(alphabet)
+	for i := range b {
+		for {
+			if r.pos >= len(r.buf) {
+				r.refill()
+			}
+			c := r.buf[r.pos]
+			r.pos++
+			if int(c) < limit {
+				b[i] = alphabet[int(c)%len(alphabet)]
+				break
+			}
+		}
+	}
+}
+
+// NumString returns a random string containing numbers.
+func (r *RandomSecure) NumString(n int) string {
+	b := make([]byte, n)
+	r.fillLetters(b, numbers)
+	return ByteSliceToString(b)
+}
+
+// Length generates an integer between min and max.
+func (r *RandomSecure) Length(min, max int) int {
+	if min > max {
+		return 0
+	}
+	if min == max {
+		return min
+	}
+	return r.intn(max-min) + min
+}
+
+// String returns a random string of random length between min and max.
+func (r *RandomSecure) String(min, max int) string {
+	length := r.Length(min, max)
+	return r.FixedLenString(length)
+}
+
+// FixedLenString returns a random string of n chars.
+func (r *RandomSecure) FixedLenString(n int) string {
+	b := make([]byte, n)
+	r.fillLetters(b, letters)
+	return ByteSliceToString(b)
+}
+
+// Bool returns a random boolean. This function uses a cache to only trigger a
+// call to the keystream every 63 calls. We generate a 63 bits number and then
+// use each bit as one random boolean.
+func (r *RandomSecure) Bool() bool {
+	if r.boolcount == 0 {
+		r.boolcache, r.boolcount = r.int63(), 63
+	}
+
+	result := r.boolcache&0x01 == 1
+	r.boolcache >>= 1
+	r.boolcount--
+
+	return result
+}
+
+// OptBool return an optional random boolean.
+func (r *RandomSecure) OptBool() *wrappers.BoolValue {
+	if !r.Bool() {
+		return nil
+	}
+	return &wrappers.BoolValue{Value: r.Bool()}
+}
+
+// Int returns a random int32.
+func (r *RandomSecure) Int(n int) int32 {
+	return int32(r.intn(n))
+}
+
+// OptInt32 returns a optional random int32.
+func (r *RandomSecure) OptInt32(n int) *wrappers.Int32Value {
+	if !r.Bool() {
+		return nil
+	}
+	return &wrappers.Int32Value{Value: r.Int(n)}
+}
+
+// OptInt64 returns a optional random int64.
+func (r *RandomSecure) OptInt64(n int) *wrappers.Int64Value {
+	if !r.Bool() {
+		return nil
+	}
+	return &wrappers.Int64Value{Value: int64(r.Int(n))}
+}
+
+// Date returns a random recent date formatted as string.
+func (r *RandomSecure) Date() string {
+	min := time.Now().AddDate(0, 0, -5).Unix() // 5 days ago
+	max := time.Now().Unix()                   // Now
+	delta := max - min
+
+	sec := r.int63n(delta) + min
+	return time.Unix(sec, 0).Format(time.RFC3339)
+}
+
+// OptString returns an optional random string of random length between min
+// and max.
+func (r *RandomSecure) OptString(min, max int) *wrappers.StringValue {
+	if !r.Bool() {
+		return nil
+	}
+	return &wrappers.StringValue{Value: r.String(min, max)}
+}
+
+// Size returns a physical measure for an object using a log-normal
+// distribution with underlying normal parameters mu and sigma.
+func (r *RandomSecure) Size(mu, sigma float64) *wrappers.Int32Value {
+	if !r.Bool() {
+		return nil
+	}
+	var size int32
+	for ; size <= 0; size = int32(math.Exp(r.normFloat64()*sigma + mu)) {
+	}
+	return &wrappers.Int32Value{Value: size}
+}
+
+// RandomId returns a random string to use as id, starting with prefix.
+func (r *RandomSecure) RandomId(prefix string) string {
+	timestamp := strconv.FormatInt(time.Now().UTC().UnixNano(), 10)
+	id := []string{prefix, r.String(10, 20), timestamp}
+	return strings.Join(id, "_")
+}
@@ -0,0 +1,98 @@
+package random // import "fluux.io/random"
+
+import "testing"
+
+// TestRandomSecureSize checks RandomSecure.Size returns valid values.
+func TestRandomSecureSize(t *testing.T) {
+	r := NewRandomSecure()
+	for i := 0; i < testsRandomNumber; i++ {
+		n := r.Size(8, 0.5)
+		if n == nil {
+			continue
+		}
+		if n.Value < 1 {
+			t.Errorf("%v < 1)", n.Value)
+		}
+	}
+}
+
+// TestRandomSecureString checks that length of string returned by String
+// is falling within the specified bounds.
+func TestRandomSecureString(t *testing.T) {
+	r := NewRandomSecure()
+
+	for i := 0; i < testsRandomNumber; i++ {
+		min := int(r.Int(100))
+		max := min + int(r.Int(100))
+		s := r.String(min, max)
+		l := len(s)
+		if l < min || l > max {
+			t.Errorf("wrong length: %q, (%d, %d))", s, min, max)
+		}
+	}
+}
+
+func TestRandomSecureFixedLen(t *testing.T) {
+	r := NewRandomSecure()
+	for i := 0; i < testsRandomNumber; i++ {
+		l := int(r.Int(100))
+		s := r.FixedLenString(l)
+		if len(s) != l {
+			t.Errorf("wrong length: %q, (%d))", l, len(s))
+		}
+	}
+}
+
+// TestRandomSecureBool checks that Bool is balanced.
+func TestRandomSecureBool(tt *testing.T) {
+	r := NewRandomSecure()
+
+	var t, f int
+	for i := 0; i < testsRandomNumber; i++ {
+		b := r.Bool()
+		if b {
+			t++
+		} else {
+			f++
+		}
+	}
+
+	min := testsRandomNumber/2 - (testsRandomNumber * 10 / 100)
+	max := testsRandomNumber/2 + (testsRandomNumber * 10 / 100)
+	if t < min || t > max || f < min || f > max {
+		tt.Errorf("Bool is not balanced, (%d true, %d false))", t, f)
+	}
+}
+
+//=============================================================================
+// Benchmarks
+//
+// Compare against BenchmarkRandomString/BenchmarkRandomId in
+// random_unsafe_test.go. The original request asked for RandomSecure to
+// stay within ~2-3x RandomUnsafe's speed; measured here it's roughly 15x
+// (BenchmarkRandomSecureString vs BenchmarkRandomString), because it draws
+// fresh keystream per character instead of slicing a shared prealloc
+// buffer. That does not meet the stated target — see the comment at the top
+// of random_secure.go for what was tried and why the remaining gap
+// is a tradeoff against unpredictability rather than a missed optimization.
+
+func BenchmarkRandomSecureString(b *testing.B) {
+	r := NewRandomSecure()
+	for i := 0; i < b.N; i++ {
+		r.String(15, 25)
+	}
+}
+
+func BenchmarkRandomSecureId(b *testing.B) {
+	r := NewRandomSecure()
+	for i := 0; i < b.N; i++ {
+		r.RandomId("test")
+	}
+}
+
+func BenchmarkRandomSecureInt(b *testing.B) {
+	r := NewRandomSecure()
+	for i := 0; i < b.N; i++ {
+		r.Int(1000)
+	}
+}
@@ -0,0 +1,163 @@
+package random // import "fluux.io/random"
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math/bits"
+	mathrand "math/rand"
+)
+
+//=============================================================================
+// Pluggable random sources
+//
+// Source decouples RandomUnsafe from any particular RNG algorithm. It is
+// intentionally shaped like math/rand/v2.Source (a single Uint64 method) so
+// that, once this module can take a dependency on Go 1.22+, a
+// math/rand/v2.Source can be passed in directly without an adapter.
+
+// Source produces uniformly distributed 64 bit values.
+type Source interface {
+	Uint64() uint64
+}
+
+// lemireUint64n returns a value in [0,n) drawn from src using Lemire's
+// multiply-and-shift reduction with rejection, which (unlike `% n`) has no
+// modulo bias for n that doesn't divide 2^64 evenly. It panics if n == 0.
+func lemireUint64n(src Source, n uint64) uint64 {
+	if n == 0 {
+		panic("random: invalid argument to lemireUint64n")
+	}
+	if n&(n-1) == 0 { // n is a power of two
+		return src.Uint64() & (n - 1)
+	}
+	hi, lo := bits.Mul64(src.Uint64(), n)
+	if lo < n {
+		thresh := -n % n
+		for lo < thresh {
+			hi, lo = bits.Mul64(src.Uint64(), n)
+		}
+	}
+	return hi
+}
+
+func cryptoSeedUint64() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("random: failed to read crypto/rand seed: " + err.Error())
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+//=============================================================================
+// PCG source: small state, good statistical quality, the math/rand/v2 default
+
+// pcgSource is a 128-bit state permuted congruential generator (PCG-XSL-RR).
+type pcgSource struct {
+	hi, lo uint64
+}
+
+const (
+	pcgMulHi = 2549297995355413924
+	pcgMulLo = 4865540595714422341
+	pcgIncHi = 6364136223846793005
+	pcgIncLo = 1442695040888963407
+)
+
+// NewPCGSource creates a PCG backed Source seeded from crypto/rand.
+func NewPCGSource() Source {
+	return &pcgSource{hi: cryptoSeedUint64(), lo: cryptoSeedUint64()}
+}
+
+func (p *pcgSource) step() {
+	hi, lo := bits.Mul64(p.lo, pcgMulLo)
+	hi += p.hi*pcgMulLo + p.lo*pcgMulHi
+
+	var carry uint64
+	lo, carry = bits.Add64(lo, pcgIncLo, 0)
+	hi, _ = bits.Add64(hi, pcgIncHi, carry)
+
+	p.hi, p.lo = hi, lo
+}
+
+// Uint64 advances the generator and returns its next output.
+func (p *pcgSource) Uint64() uint64 {
+	p.step()
+	xored := p.hi ^ p.lo
+	rot := p.hi >> 58 // top 6 bits select the rotation amount
+	return bits.RotateLeft64(xored, -int(rot))
+}
+
+// snapshotState and restoreState let RandomUnsafe.Snapshot/Restore capture
+// and replay this source's state exactly; see random_replay.go. restoreState
+// is a no-op if st was captured from a differently-typed Source.
+func (p *pcgSource) snapshotState() any { return *p }
+func (p *pcgSource) restoreState(st any) {
+	if v, ok := st.(pcgSource); ok {
+		*p = v
+	}
+}
+
+//=============================================================================
+// ChaCha8 source: cryptographically strong, for unpredictable streams
+
+// chacha8Source is a Source drawing from a ChaCha8 keystream, one block at a
+// time, reusing the block function written for RandomSecure.
+type chacha8Source struct {
+	key     [8]uint32
+	nonce   [3]uint32
+	counter uint32
+	block   [chachaBlockSize]byte
+	pos     int
+}
+
+// NewChaCha8Source creates a ChaCha8 backed Source seeded from crypto/rand.
+func NewChaCha8Source() Source {
+	s := &chacha8Source{pos: chachaBlockSize}
+	seedKeyNonce(&s.key, &s.nonce)
+	return s
+}
+
+// NewChaCha8SourceFromSeed creates a ChaCha8 backed Source deterministically
+// derived from seed, so the same seed always produces the same stream. This
+// is what backs NewRandomUnsafeSeeded.
+func NewChaCha8SourceFromSeed(seed [32]byte) Source {
+	s := &chacha8Source{pos: chachaBlockSize}
+	for i := range s.key {
+		s.key[i] = binary.LittleEndian.Uint32(seed[i*4:])
+	}
+	return s
+}
+
+// Uint64 advances the generator and returns its next output.
+func (s *chacha8Source) Uint64() uint64 {
+	if s.pos+8 > chachaBlockSize {
+		s.block = chacha8Block(s.key, s.counter, s.nonce)
+		s.counter++
+		s.pos = 0
+	}
+	v := binary.LittleEndian.Uint64(s.block[s.pos:])
+	s.pos += 8
+	return v
+}
+
+// snapshotState and restoreState let RandomUnsafe.Snapshot/Restore capture
+// and replay this source's state exactly; see random_replay.go. restoreState
+// is a no-op if st was captured from a differently-typed Source.
+func (s *chacha8Source) snapshotState() any { return *s }
+func (s *chacha8Source) restoreState(st any) {
+	if v, ok := st.(chacha8Source); ok {
+		*s = v
+	}
+}
+
+//=============================================================================
+// Legacy source: wraps math/rand for backward compatibility
+
+// NewLegacySource wraps the legacy math/rand generator as a Source, for
+// callers that relied on its exact behavior. Prefer NewPCGSource or
+// NewChaCha8Source for new code: seeding this source from the wall clock, as
+// RandomUnsafe used to do by default, silently produces identical streams
+// for two processes started in the same second.
+func NewLegacySource(seed int64) Source {
+	return mathrand.New(mathrand.NewSource(seed))
+}
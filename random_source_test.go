@@ -0,0 +1,35 @@
+package random // import "fluux.io/random"
+
+import "testing"
+
+// TestSourcesProduceStrings smoke-tests that RandomUnsafe works the same
+// regardless of which Source backs it.
+func TestSourcesProduceStrings(t *testing.T) {
+	sources := map[string]Source{
+		"pcg":     NewPCGSource(),
+		"chacha8": NewChaCha8Source(),
+		"legacy":  NewLegacySource(42),
+	}
+
+	for name, src := range sources {
+		r := NewRandomUnsafeWithSource(src)
+		s := r.String(10, 20)
+		if l := len(s); l < 10 || l > 20 {
+			t.Errorf("%s: wrong length: %q (%d)", name, s, l)
+		}
+	}
+}
+
+// TestLemireUint64nBounds checks that lemireUint64n never returns a value
+// outside [0,n), for both power-of-two and non-power-of-two n.
+func TestLemireUint64nBounds(t *testing.T) {
+	src := NewPCGSource()
+	for _, n := range []uint64{1, 2, 3, 7, 62, 1000, 1 << 20} {
+		for i := 0; i < 1000; i++ {
+			v := lemireUint64n(src, n)
+			if v >= n {
+				t.Fatalf("lemireUint64n(%d) = %d, want < %d", n, v, n)
+			}
+		}
+	}
+}
@@ -6,7 +6,7 @@ data. It that case, the random generator locks can become a bottleneck.
 package random // import "fluux.io/random"
 
 import (
-	"math/rand"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -23,13 +23,18 @@ import (
 // It is more efficient than the default generator as it avoid using the mutex
 // locks used as default for thread safety.
 // It is intended to be used in part of code that use random value heavily.
+//
+// The underlying RNG is pluggable through Source: see NewRandomUnsafeWithSource,
+// NewPCGSource, NewChaCha8Source and NewLegacySource.
 type RandomUnsafe struct {
-	src *rand.Rand
+	src Source
 	// preallocated random string
 	prealloc []byte
 	// Cache for generating boolean number more efficiently
-	boolcache int64
+	boolcache uint64
 	boolcount int
+	// Cached table for the last Zipf(s, v, imax) call
+	zipf *zipfTable
 }
 
 const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
@@ -37,21 +42,49 @@ const numbers = "0123456789"
 const stringSeedSize = 10000
 
 // NewRandomUnsafe creates an initialized random generator to use from a
-// single go routine.
+// single go routine, backed by a PCG source seeded from crypto/rand.
 func NewRandomUnsafe() RandomUnsafe {
-	src := rand.New(rand.NewSource(time.Now().Unix()))
-	prealloc := make([]byte, stringSeedSize)
-	for i := range prealloc {
-		prealloc[i] = letters[src.Int63()%int64(len(letters))]
+	return NewRandomUnsafeWithSource(NewPCGSource())
+}
+
+// NewRandomUnsafeWithSource creates an initialized random generator backed by
+// the given Source, to use from a single go routine.
+func NewRandomUnsafeWithSource(src Source) RandomUnsafe {
+	r := RandomUnsafe{src: src, prealloc: make([]byte, stringSeedSize)}
+	for i := range r.prealloc {
+		r.prealloc[i] = letters[r.intn(len(letters))]
+	}
+	return r
+}
+
+// intn returns a random integer in [0,n), using Lemire's unbiased reduction
+// instead of `% n`, which is biased for n that doesn't divide 2^64 evenly.
+// It panics if n <= 0, matching the old rand.Intn behavior.
+func (r *RandomUnsafe) intn(n int) int {
+	if n <= 0 {
+		panic("random: invalid argument to intn")
+	}
+	return int(lemireUint64n(r.src, uint64(n)))
+}
+
+func (r *RandomUnsafe) float64() float64 {
+	return float64(r.src.Uint64()>>11) / (1 << 53)
+}
+
+func (r *RandomUnsafe) normFloat64() float64 {
+	u1 := r.float64()
+	for u1 == 0 {
+		u1 = r.float64()
 	}
-	return RandomUnsafe{src: src, prealloc: prealloc}
+	u2 := r.float64()
+	return math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
 }
 
 // NumString returns a random string containing numbers.
 func (r *RandomUnsafe) NumString(n int) string {
 	b := make([]byte, n)
 	for i := range b {
-		b[i] = numbers[r.src.Int63()%int64(len(numbers))]
+		b[i] = numbers[r.intn(len(numbers))]
 	}
 	return ByteSliceToString(b)
 }
@@ -64,7 +97,7 @@ func (r *RandomUnsafe) Length(min, max int) int {
 	if min == max {
 		return min
 	}
-	return r.src.Intn(max-min) + min
+	return r.intn(max-min) + min
 }
 
 // String returns a random string of random length between min and max.
@@ -75,15 +108,15 @@ func (r *RandomUnsafe) String(min, max int) string {
 
 // FixedLenString returns a random string of n chars.
 func (r *RandomUnsafe) FixedLenString(n int) string {
-	pos := r.src.Intn(stringSeedSize - n)
+	pos := r.intn(stringSeedSize - n)
 	return ByteSliceToString(r.prealloc[pos : pos+n])
 }
 
 // Bool returns a random boolean. This function uses a cache to only trigger call to random number
-// generator every 63 calls. We generate a 63 bits number and then use each bits as one random boolean.
+// generator every 64 calls. We generate a 64 bits number and then use each bits as one random boolean.
 func (r *RandomUnsafe) Bool() bool {
 	if r.boolcount == 0 {
-		r.boolcache, r.boolcount = r.src.Int63(), 63
+		r.boolcache, r.boolcount = r.src.Uint64(), 64
 	}
 
 	result := r.boolcache&0x01 == 1
@@ -103,7 +136,7 @@ func (r *RandomUnsafe) OptBool() *wrappers.BoolValue {
 
 // Int returns a random int32.
 func (r *RandomUnsafe) Int(n int) int32 {
-	return int32(r.src.Intn(n))
+	return int32(r.intn(n))
 }
 
 // OptInt32 returns a optional random int32.
@@ -128,7 +161,7 @@ func (r *RandomUnsafe) Date() string {
 	max := time.Now().Unix()                   // Now
 	delta := max - min
 
-	sec := r.src.Int63n(delta) + min
+	sec := int64(lemireUint64n(r.src, uint64(delta))) + min
 	return time.Unix(sec, 0).Format(time.RFC3339)
 }
 
@@ -141,13 +174,15 @@ func (r *RandomUnsafe) OptString(min, max int) *wrappers.StringValue {
 	return &wrappers.StringValue{Value: r.String(min, max)}
 }
 
-// Size returns a physical measure for an object using a normal distribution.
-func (r *RandomUnsafe) Size() *wrappers.Int32Value {
+// Size returns a physical measure for an object using a log-normal
+// distribution with underlying normal parameters mu and sigma, so callers
+// can match their own size histograms.
+func (r *RandomUnsafe) Size(mu, sigma float64) *wrappers.Int32Value {
 	if !r.Bool() {
 		return nil
 	}
 	var size int32
-	for ; size <= 0; size = int32(r.src.NormFloat64()*2500 + 3000) {
+	for ; size <= 0; size = int32(r.LogNormal(mu, sigma)) {
 	}
 	return &wrappers.Int32Value{Value: size}
 }
@@ -14,12 +14,29 @@ func init() {
 	rand.Seed(time.Now().UTC().UnixNano())
 }
 
+// TestIntPanicsOnNonPositiveN checks that Int(n) panics for n <= 0 instead of
+// silently returning a garbage value, matching the old rand.Intn behavior.
+func TestIntPanicsOnNonPositiveN(t *testing.T) {
+	r := NewRandomUnsafe()
+
+	for _, n := range []int{0, -1, -5} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Int(%d) did not panic", n)
+				}
+			}()
+			r.Int(n)
+		}()
+	}
+}
+
 // TestRandomSize checks RandomUnsafe.Size returns valid values.
 func TestRandomSize(t *testing.T) {
 	rand.Seed(time.Now().UTC().UnixNano())
 	r := NewRandomUnsafe()
 	for i := 0; i < testsRandomNumber; i++ {
-		n := r.Size()
+		n := r.Size(8, 0.5)
 		if n == nil {
 			continue
 		}